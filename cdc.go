@@ -0,0 +1,100 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"math/bits"
+
+	mapset "github.com/deckarep/golang-set"
+)
+
+// Content-defined chunking parameters. mask is sized for an average chunk of
+// ~8KB (2^13); min/max bound the worst case where the rolling hash goes a
+// long stretch without hitting a boundary (or hits one immediately).
+const (
+	cdcWindowSize = 64
+	cdcMask       = (1 << 13) - 1
+	cdcMinChunk   = 2 * 1024
+	cdcMaxChunk   = 64 * 1024
+)
+
+// buzhashTable holds one random 64-bit word per byte value. roll() XORs the
+// incoming byte's word into a rotated accumulator and, once the window is
+// full, XORs the outgoing byte's (correspondingly rotated) word back out -
+// the standard buzhash trick for an O(1) update per byte.
+var buzhashTable [256]uint64
+
+func init() {
+	seed := uint64(0x9e3779b97f4a7c15)
+	for i := range buzhashTable {
+		seed ^= seed << 13
+		seed ^= seed >> 7
+		seed ^= seed << 17
+		buzhashTable[i] = seed
+	}
+}
+
+// buzhash is a rolling hash over a fixed-size window of bytes.
+type buzhash struct {
+	sum uint64
+}
+
+func (h *buzhash) roll(b byte, window []byte, i, size int) {
+	h.sum = bits.RotateLeft64(h.sum, 1) ^ buzhashTable[b]
+	if i >= size {
+		h.sum ^= bits.RotateLeft64(buzhashTable[window[i-size]], size%64)
+	}
+}
+
+func (h *buzhash) reset() {
+	h.sum = 0
+}
+
+// CDCExtractor splits a file into content-defined chunks using a rolling
+// hash and returns the sha256 of each chunk as a set member. Because chunk
+// boundaries are determined by local content rather than fixed offsets,
+// shifting or reordering bytes elsewhere in the file doesn't change most
+// chunk hashes, which is what makes Jaccard similarity meaningful on
+// binaries.
+type CDCExtractor struct{}
+
+// Extract reads fullpath and returns its CDC chunk-hash set.
+func (CDCExtractor) Extract(fullpath string) (mapset.Set, error) {
+	data, err := ioutil.ReadFile(fullpath)
+	if err != nil {
+		return nil, err
+	}
+
+	chunkSet := mapset.NewSet()
+	start := 0
+	var h buzhash
+
+	for i, b := range data {
+		// The window restarts at each chunk start, matching h.reset(), so
+		// roll never removes a byte it did not add.
+		h.roll(b, data[start:], i-start, cdcWindowSize)
+
+		size := i - start + 1
+		if size < cdcMinChunk {
+			continue
+		}
+
+		if size >= cdcMaxChunk || (h.sum&cdcMask) == cdcMask {
+			chunkSet.Add(hashChunk(data[start : i+1]))
+			start = i + 1
+			h.reset()
+		}
+	}
+
+	if start < len(data) {
+		chunkSet.Add(hashChunk(data[start:]))
+	}
+
+	return chunkSet, nil
+}
+
+func hashChunk(chunk []byte) string {
+	sum := sha256.Sum256(chunk)
+	return hex.EncodeToString(sum[:])
+}
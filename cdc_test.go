@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCDCExtractorDeterministic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.bin")
+	data := make([]byte, 200*1024)
+	for i := range data {
+		data[i] = byte(i * 31 % 256)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var extractor CDCExtractor
+	first, err := extractor.Extract(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := extractor.Extract(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !first.Equal(second) {
+		t.Fatal("CDC chunking must be deterministic for the same file")
+	}
+	if first.Cardinality() == 0 {
+		t.Fatal("expected at least one chunk")
+	}
+}
+
+func TestCDCExtractorDiffersOnContent(t *testing.T) {
+	dir := t.TempDir()
+	pathA := filepath.Join(dir, "a.bin")
+	pathB := filepath.Join(dir, "b.bin")
+
+	dataA := make([]byte, 100*1024)
+	dataB := make([]byte, 100*1024)
+	for i := range dataA {
+		dataA[i] = byte(i % 256)
+		dataB[i] = byte((i + 1) % 256)
+	}
+
+	if err := os.WriteFile(pathA, dataA, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pathB, dataB, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var extractor CDCExtractor
+	setA, err := extractor.Extract(pathA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setB, err := extractor.Extract(pathB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if setA.Equal(setB) {
+		t.Fatal("expected different content to produce different chunk sets")
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bufio"
+	"compress/flate"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// checkpointChunkEntries bounds how many entries land in one log chunk
+// before it's rotated out and a fresh one opened. Smaller chunks mean less
+// work is lost if the process dies mid-chunk, at the cost of slightly worse
+// compression across chunk boundaries.
+const checkpointChunkEntries = 4096
+
+// manifest pins a checkpoint to the inputs and config it was produced
+// with. --resume only replays a checkpoint whose manifest matches the
+// current run exactly.
+type manifest struct {
+	FilesHash string  `json:"filesHash"`
+	Features  string  `json:"features"`
+	Threshold float32 `json:"threshold"`
+	MinLen    int     `json:"minLen"`
+	Encoding  string  `json:"encoding"`
+}
+
+func manifestPath(tempFolder string) string {
+	return filepath.Join(tempFolder, "_jaccard_manifest.json")
+}
+
+func hashFileList(filePaths []string) string {
+	h := sha256.New()
+	for _, p := range filePaths {
+		fmt.Fprintln(h, p)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func buildManifest(filePaths []string, userArgs *args) manifest {
+	return manifest{
+		FilesHash: hashFileList(filePaths),
+		Features:  userArgs.features,
+		Threshold: userArgs.jaccardThreshold,
+		MinLen:    userArgs.minLen,
+		Encoding:  userArgs.encoding,
+	}
+}
+
+func writeManifest(tempFolder string, m manifest) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(manifestPath(tempFolder), data, 0644)
+}
+
+func readManifest(tempFolder string) (manifest, error) {
+	var m manifest
+	data, err := os.ReadFile(manifestPath(tempFolder))
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
+
+// checkpointChunkGlob matches every log chunk file newCheckpointLog can
+// produce, regardless of tempFolder.
+func checkpointChunkGlob(tempFolder string) string {
+	return filepath.Join(tempFolder, "_jaccard_log_*")
+}
+
+// existingChunkCount returns how many log chunks are already on disk, so a
+// resumed run's checkpoint log can continue numbering after them instead of
+// overwriting chunk 0.
+func existingChunkCount(tempFolder string) (int, error) {
+	chunkFiles, err := filepath.Glob(checkpointChunkGlob(tempFolder))
+	if err != nil {
+		return 0, err
+	}
+	return len(chunkFiles), nil
+}
+
+// purgeCheckpoint removes a previous run's manifest and log chunks. It must
+// be called before starting a fresh (non-resumed, or resume-mismatched)
+// run, otherwise chunks left over from a longer prior run would survive
+// untouched and be incorrectly replayed by some later --resume.
+func purgeCheckpoint(tempFolder string) error {
+	if err := os.Remove(manifestPath(tempFolder)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	chunkFiles, err := filepath.Glob(checkpointChunkGlob(tempFolder))
+	if err != nil {
+		return err
+	}
+	for _, f := range chunkFiles {
+		if err := os.Remove(f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isCheckpointFile reports whether path is one of this tool's own
+// manifest/log bookkeeping files living directly in tempFolder, so callers
+// can exclude it from a scanned file list.
+func isCheckpointFile(path, tempFolder string) bool {
+	absTemp, err := filepath.Abs(tempFolder)
+	if err != nil {
+		return false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
+	return filepath.Dir(absPath) == absTemp && strings.HasPrefix(filepath.Base(absPath), "_jaccard")
+}
+
+// excludeCheckpointFiles drops this tool's own manifest/log bookkeeping
+// files from a scanned file list, so pointing --resume's temp folder at (or
+// under) the target directory doesn't feed them into the comparisons.
+func excludeCheckpointFiles(filePaths []string, tempFolder string) []string {
+	filtered := make([]string, 0, len(filePaths))
+	for _, p := range filePaths {
+		if !isCheckpointFile(p, tempFolder) {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+// logEntry is one scored pair as recorded in the checkpoint log. index is
+// this pair's position in the deterministic itertools.CombinationsStr
+// enumeration of the (sorted) file list, which is what lets --resume skip
+// pairs it has already scored.
+type logEntry struct {
+	Index int     `json:"i"`
+	PathA string  `json:"a"`
+	PathB string  `json:"b"`
+	Score float32 `json:"s"`
+}
+
+// checkpointLog is the append-only, chunked, flate-compressed log that
+// generateCombinations used to pre-materialize the full pair list into.
+// Here it's repurposed: rather than spooling every combination before any
+// work happens, entries are appended as pairs are actually scored, and the
+// log itself is what --resume replays to skip already-scored pairs.
+type checkpointLog struct {
+	mu         sync.Mutex
+	tempFolder string
+	chunk      int
+	file       *os.File
+	compressor *flate.Writer
+	entries    int
+}
+
+// newCheckpointLog opens a checkpoint log that starts numbering chunks at
+// startChunk, so a resumed run appends new chunks after the ones it just
+// replayed instead of overwriting them.
+func newCheckpointLog(tempFolder string, startChunk int) (*checkpointLog, error) {
+	l := &checkpointLog{tempFolder: tempFolder, chunk: startChunk}
+	if err := l.rotate(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *checkpointLog) rotate() error {
+	if l.compressor != nil {
+		l.compressor.Close()
+		l.file.Close()
+	}
+
+	name := filepath.Join(l.tempFolder, fmt.Sprintf("_jaccard_log_%04d", l.chunk))
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	w, err := flate.NewWriter(f, flate.DefaultCompression)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	l.file = f
+	l.compressor = w
+	l.entries = 0
+	l.chunk++
+	return nil
+}
+
+// Append records a scored pair and flushes so the entry survives a crash
+// even though the chunk's flate stream is never explicitly closed until
+// rotation.
+func (l *checkpointLog) Append(e logEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.compressor.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	if err := l.compressor.Flush(); err != nil {
+		return err
+	}
+
+	l.entries++
+	if l.entries >= checkpointChunkEntries {
+		return l.rotate()
+	}
+	return nil
+}
+
+// Close flushes and closes the current chunk.
+func (l *checkpointLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.compressor == nil {
+		return nil
+	}
+	if err := l.compressor.Close(); err != nil {
+		return err
+	}
+	return l.file.Close()
+}
+
+// readCheckpointLog replays every chunk in tempFolder in order, returning
+// the entries recorded so far. A chunk left mid-write by a crash decodes
+// cleanly up to its last flushed entry and is truncated there.
+func readCheckpointLog(tempFolder string) ([]logEntry, error) {
+	chunkFiles, err := filepath.Glob(filepath.Join(tempFolder, "_jaccard_log_*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(chunkFiles)
+
+	var entries []logEntry
+	for _, path := range chunkFiles {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		r := flate.NewReader(f)
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			var e logEntry
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+				break
+			}
+			entries = append(entries, e)
+		}
+		r.Close()
+		f.Close()
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManifestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	m := manifest{FilesHash: "abc", Features: "strings", Threshold: 0.5, MinLen: 4, Encoding: "ascii"}
+
+	if err := writeManifest(dir, m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != m {
+		t.Fatalf("expected %+v, got %+v", m, got)
+	}
+}
+
+func TestCheckpointLogAppendAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	log, err := newCheckpointLog(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []logEntry{
+		{Index: 0, PathA: "a", PathB: "b", Score: 0.9},
+		{Index: 1, PathA: "a", PathB: "c", Score: 0.1},
+	}
+	for _, e := range entries {
+		if err := log.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	replayed, err := readCheckpointLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replayed) != len(entries) {
+		t.Fatalf("expected %d replayed entries, got %d", len(entries), len(replayed))
+	}
+	for i, e := range entries {
+		if replayed[i] != e {
+			t.Fatalf("entry %d: expected %+v, got %+v", i, e, replayed[i])
+		}
+	}
+}
+
+func TestNewCheckpointLogContinuesChunkNumbering(t *testing.T) {
+	dir := t.TempDir()
+	log, err := newCheckpointLog(dir, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Append(logEntry{Index: 0, PathA: "a", PathB: "b", Score: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "_jaccard_log_0003")); err != nil {
+		t.Fatalf("expected chunk numbering to start at 3, got: %v", err)
+	}
+}
+
+func TestPurgeCheckpointRemovesStaleChunks(t *testing.T) {
+	dir := t.TempDir()
+	log, err := newCheckpointLog(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Append(logEntry{Index: 0, PathA: "a", PathB: "b", Score: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeManifest(dir, manifest{FilesHash: "x"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := purgeCheckpoint(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := existingChunkCount(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Fatalf("expected stale chunks to be purged, found %d", n)
+	}
+	if _, err := os.Stat(manifestPath(dir)); !os.IsNotExist(err) {
+		t.Fatal("expected manifest to be removed")
+	}
+}
+
+func TestExcludeCheckpointFiles(t *testing.T) {
+	dir := t.TempDir()
+	real := filepath.Join(dir, "real.txt")
+	if err := os.WriteFile(real, []byte("hi"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeManifest(dir, manifest{FilesHash: "x"}); err != nil {
+		t.Fatal(err)
+	}
+
+	filtered := excludeCheckpointFiles([]string{real, manifestPath(dir)}, dir)
+	if len(filtered) != 1 || filtered[0] != real {
+		t.Fatalf("expected only the real file to remain, got %v", filtered)
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	mapset "github.com/deckarep/golang-set"
+)
+
+// FeatureExtractor turns a file on disk into the set of features that
+// jaccard() compares. Different extractors trade off what "similar" means:
+// the strings extractor is good for text-ish files, CDC is good for binaries.
+type FeatureExtractor interface {
+	Extract(fullpath string) (mapset.Set, error)
+}
+
+// featureExtractors is the --features registry, keyed by CLI flag value.
+// Each entry is a factory rather than a bare instance so it can pick up
+// extractor-specific flags (e.g. --min-len, --encoding) from userArgs. Add
+// an entry here to make a new extractor selectable from the CLI.
+var featureExtractors = map[string]func(*args) FeatureExtractor{
+	"strings": func(a *args) FeatureExtractor {
+		return StringsExtractor{MinLen: a.minLen, Encoding: a.encoding}
+	},
+	"cdc": func(a *args) FeatureExtractor {
+		return CDCExtractor{}
+	},
+}
@@ -1,51 +1,119 @@
 package main
 
 import (
-	"bytes"
-	"compress/flate"
+	"context"
+	"flag"
 	"fmt"
-	"io/ioutil"
-	"math"
 	"os"
-	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"runtime"
 	"strconv"
-	"strings"
 
-	"github.com/c2h5oh/datasize"
 	mapset "github.com/deckarep/golang-set"
-	"github.com/ernestosuarez/itertools"
-	ffmt "gopkg.in/ffmt.v1"
 )
 
 type args struct {
 	targetPath       string
 	jaccardThreshold float32
 	tempFolder       string
+	features         string
+	approx           bool
+	numHashes        int
+	bands            int
+	rows             int
+	seed             int64
+	output           string
+	format           string
+	workers          int
+	resume           bool
+	minLen           int
+	encoding         string
 }
 
 // get user input
 func input() *args {
-	if len(os.Args) < 3 {
+	features := flag.String("features", "strings", "feature extractor to use (strings|cdc)")
+	approx := flag.Bool("approx", false, "use MinHash + LSH banding to find candidate pairs instead of comparing every pair")
+	numHashes := flag.Int("num-hashes", 128, "number of MinHash functions (--approx); must equal bands*rows")
+	bands := flag.Int("bands", 32, "number of LSH bands (--approx)")
+	rows := flag.Int("rows", 4, "number of rows per LSH band (--approx)")
+	seed := flag.Int64("seed", 1, "seed for the MinHash hash family (--approx); fixed by default so repeat runs are reproducible")
+	output := flag.String("output", "", "write results to this file instead of stdout")
+	format := flag.String("format", "text", "output format: text|csv|ndjson|json")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of concurrent comparison workers, 1..NumCPU")
+	resume := flag.Bool("resume", false, "resume from the checkpoint log in the temp folder, if its manifest matches this run")
+	minLen := flag.Int("min-len", defaultMinLen, "minimum run length for the strings extractor")
+	encoding := flag.String("encoding", "ascii", "character encoding for the strings extractor: ascii|utf8|utf16le|utf16be|all")
+	flag.Parse()
+
+	positional := flag.Args()
+	if len(positional) < 2 {
 		fmt.Println("Usage:")
-		fmt.Println("jaccard <target folder> <jaccard threshold> [temp folder]")
+		fmt.Println("jaccard [--features strings|cdc] [--approx --num-hashes N --bands B --rows R] <target folder> <jaccard threshold> [temp folder]")
 		os.Exit(1)
 	}
 
-	thresh, _ := strconv.ParseFloat(os.Args[2], 32)
+	if _, ok := featureExtractors[*features]; !ok {
+		fmt.Printf("Unknown feature extractor %q\n", *features)
+		os.Exit(1)
+	}
+
+	if *approx && *bands**rows != *numHashes {
+		fmt.Printf("--num-hashes (%d) must equal --bands * --rows (%d * %d)\n", *numHashes, *bands, *rows)
+		os.Exit(1)
+	}
+
+	if *approx && *resume {
+		fmt.Println("--resume is not supported with --approx")
+		os.Exit(1)
+	}
+
+	if _, ok := formatters[*format]; !ok {
+		fmt.Printf("Unknown output format %q\n", *format)
+		os.Exit(1)
+	}
+
+	if *workers < 1 || *workers > runtime.NumCPU() {
+		fmt.Printf("--workers must be between 1 and %d (NumCPU)\n", runtime.NumCPU())
+		os.Exit(1)
+	}
+
+	if _, err := encodingsFor(*encoding); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if *minLen < 1 {
+		fmt.Println("--min-len must be at least 1")
+		os.Exit(1)
+	}
+
+	thresh, _ := strconv.ParseFloat(positional[1], 32)
 
 	// use executable folder unless user specifies a path
 	tmpPath, _ := os.Executable()
 	tmpPath = filepath.Dir(tmpPath)
-	if len(os.Args) == 4 {
-		tmpPath = os.Args[3]
+	if len(positional) == 3 {
+		tmpPath = positional[2]
 	}
 
 	userArgs := &args{
-		targetPath:       os.Args[1],
+		targetPath:       positional[0],
 		jaccardThreshold: float32(thresh),
 		tempFolder:       tmpPath,
+		features:         *features,
+		approx:           *approx,
+		numHashes:        *numHashes,
+		bands:            *bands,
+		rows:             *rows,
+		seed:             *seed,
+		output:           *output,
+		format:           *format,
+		workers:          *workers,
+		resume:           *resume,
+		minLen:           *minLen,
+		encoding:         *encoding,
 	}
 
 	return userArgs
@@ -80,201 +148,89 @@ func jaccard(set1 mapset.Set, set2 mapset.Set) float32 {
 	return float32(intersectionLength) / float32(unionLength)
 }
 
-// return a set of strings
-func getstrings(fullpath string) mapset.Set {
-	fileStrings, _ := exec.Command("strings", fullpath).CombinedOutput()
-	strArray := strings.Split(string(fileStrings), "\n")
-	stringSet := mapset.NewSet()
-
-	for _, s := range strArray {
-		stringSet.Add(s)
-	}
-
-	return stringSet
-}
-
-// threaded function for processing jaccard distances and combinations
-func process(filePaths []string, userArgs *args, numberOfFiles int, ch chan string) {
-	fileFeatures := make(map[string]mapset.Set, numberOfFiles)
-
-	// create a map of file paths / string arrays
-	for _, path := range filePaths {
-		features := getstrings(path)
-		fileFeatures[path] = features
-	}
-
-	for fArray := range itertools.CombinationsStr(filePaths, 2) {
-		jaccardIndex := jaccard(fileFeatures[fArray[0]], fileFeatures[fArray[1]])
-
-		if jaccardIndex > userArgs.jaccardThreshold {
-			fmt.Printf("%s -- %s :: %f\n", fArray[0], fArray[1], jaccardIndex)
-		}
-	}
-
-	ch <- "done"
-}
-
-// wait for threads to return
-func threadWait(ch chan []string, callerSignal chan bool, threadReturn chan []interface{}, size int) {
-	returnContainer := make([]interface{}, size)
-	itemCounter := 0
-
-	for itemCounter < (size - 1) {
-		ret := <-ch
-		//fmt.Println("Thread finished")
-		returnContainer[itemCounter] = ret
-		callerSignal <- true // signal to caller when we see a thread return
-		itemCounter++
-	}
-
-	threadReturn <- returnContainer
-}
-
-func generateCombinations(filePaths []string, size int, tmpDir string, ch chan []string) {
-	compressData := new(bytes.Buffer)
-	compressor, _ := flate.NewWriter(compressData, 1)
-	tmpFile := make([]*os.File, 1)
-	round := 0
-	tmpFile[round], _ = ioutil.TempFile(tmpDir, "_jaccard")
-
-	for fArray := range itertools.CombinationsStr(filePaths, 2) {
-		fString := fmt.Sprintf("%s,%s\n", fArray[0], fArray[1])
-		data := []byte(fString)
-		compressor.Write(data)
-
-		/**
-		  check to see if the compressed file size is growing too large.
-		  if so, wrap up and close the compression handle, write the file
-		  out, and open a new file and compression handle to continue
-		  draining Combinations()
-		  **/
-		if uint64(compressData.Len()) >= uint64(400*datasize.MB) {
-			fmt.Println("Size: ", compressData.Len()/1024/1024)
-
-			compressor.Close()
-			tmpFile[round].Write(compressData.Bytes())
-			tmpFile[round].Close()
-
-			compressData = new(bytes.Buffer)
-			compressor, _ = flate.NewWriter(compressData, 5)
-			newTmp, _ := ioutil.TempFile(tmpDir, "_jaccard")
-			tmpFile = append(tmpFile, newTmp)
-			round++
-		}
-	}
-
-	compressor.Close()
-	tmpFile[round].Write(compressData.Bytes())
-	tmpFile[round].Close()
-
-	tmpFileNames := make([]string, len(tmpFile))
-	for i, obj := range tmpFile {
-		tmpFileNames[i] = obj.Name()
-	}
-
-	ch <- tmpFileNames
-}
-
 // main
 func main() {
-	threads := runtime.NumCPU()
-	_ = runtime.GOMAXPROCS(threads)
-	ch := make(chan []string, threads)
-	callerSignal := make(chan bool, threads)
-	threadReturn := make(chan []interface{}, 1)
 	userArgs := input()
 	allFilePaths, _ := FilePathWalkDir(userArgs.targetPath)
-
-	block := threads * 2 // arbitrary value to decrease memory usage. higher == lower mem
-	splitFiles := make([][]string, block)
-	numberOfFiles := len(allFilePaths)
-
-	split := int(math.Floor(float64(numberOfFiles / block)))
-
-	// use block to generate all combinations of file pairs.
-	// combinations will be stored to temporary files
-	for i := 0; i <= (block - 1); i++ {
-		start := i * split
-
-		if i+1 == block {
-			splitFiles[i] = allFilePaths[start:]
-			break
+	allFilePaths = excludeCheckpointFiles(allFilePaths, userArgs.tempFolder)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		<-interrupts
+		fmt.Println("\nInterrupted, stopping in-flight work...")
+		cancel()
+	}()
+
+	collector := &ResultCollector{}
+
+	var err error
+	if userArgs.approx {
+		err = runApprox(ctx, allFilePaths, userArgs, collector)
+	} else {
+		skip := map[int]bool{}
+		currentManifest := buildManifest(allFilePaths, userArgs)
+		resumed := false
+
+		if userArgs.resume {
+			if prevManifest, err := readManifest(userArgs.tempFolder); err == nil && prevManifest == currentManifest {
+				entries, err := readCheckpointLog(userArgs.tempFolder)
+				if err != nil {
+					fmt.Println("error reading checkpoint log:", err)
+					os.Exit(1)
+				}
+				for _, e := range entries {
+					skip[e.Index] = true
+					if e.Score > userArgs.jaccardThreshold {
+						collector.Add(e.PathA, e.PathB, e.Score)
+					}
+				}
+				fmt.Printf("Resuming: %d pairs already scored\n", len(entries))
+				resumed = true
+			} else {
+				fmt.Println("No matching checkpoint found, starting a fresh run")
+			}
 		}
 
-		end := start + split
-		splitFiles[i] = allFilePaths[start:end]
-	}
-	fmt.Printf("Split: %d, splitFiles Len: %d\n", split, len(splitFiles))
-
-	/**
-	  we're splitting up the files (splitFiles) due to memory constraints, but
-	  we still have to compare every single file all the others, despite multiple
-	  isolated threads.
-
-	  here we generate all the index combinations of block, which will be
-	  identical to the number of indexed sub-arrays in splitFiles. index
-	  combos are stored in 'listCombos', and then we'll pass these combinations
-	  to the threads
-	  **/
-	var listCombos [][]int
-	for listIndexPair := range itertools.GenCombinations(block, 2) {
-		listCombos = append(listCombos, listIndexPair)
-	}
-	//ffmt.Print(listCombos)
-	fmt.Println("Combos: ", len(listCombos))
-
-	// setup thread handler with the number of tasks (len(listCombos))
-	comboCount := len(listCombos)
-
-	/**
-	  this *should* just make a new slice that points to a larger
-	  section of the original array?
-	  **/
-	for cnt, combo := range listCombos {
-		ffmt.Println("cnt: ", cnt, "combo: ", combo)
-		comboSlice := make([]string, len(splitFiles[combo[0]]))
-		//copy(comboSlice, splitFiles[combo[0]])
-		comboSlice = append(comboSlice, splitFiles[combo[1]]...)
-		//ffmt.Print("comboSlice", comboSlice)
-
-		if cnt < threads {
-			go generateCombinations(comboSlice, split, userArgs.tempFolder, ch)
-			//fmt.Printf("Thread %d away!\n", cnt)
-		} else if cnt == threads {
-			go threadWait(ch, callerSignal, threadReturn, comboCount)
-			//fmt.Println("kicked off threadWait")
-		} else if <-callerSignal {
-			//fmt.Println("Sending task ", cnt)
-			go generateCombinations(comboSlice, split, userArgs.tempFolder, ch)
-		} else {
-			fmt.Println("stuck in 'else' land")
+		// A fresh run (no --resume, or --resume with a stale/missing
+		// manifest) must not leave a previous run's chunks lying around for
+		// some later --resume to incorrectly replay.
+		startChunk := 0
+		if resumed {
+			n, err := existingChunkCount(userArgs.tempFolder)
+			if err != nil {
+				fmt.Println("error counting checkpoint chunks:", err)
+				os.Exit(1)
+			}
+			startChunk = n
+		} else if err := purgeCheckpoint(userArgs.tempFolder); err != nil {
+			fmt.Println("error purging stale checkpoint:", err)
+			os.Exit(1)
 		}
-	}
-
-	//tempFileNames := <-threadReturn
-	//fmt.Println(tempFileNames)
-
-	/**
-	  split up the work so we can use go block. we roughly (floor) divide
-	  the number of files by block available, take equally sized slices of
-	  file paths and store them in taskFiles, and make sure the last dump
-	  gets everything else
-	  **/
 
-	/**
-	    for i := 0; i <= (block-1); i++ {
-	        start := i * split
+		if err := writeManifest(userArgs.tempFolder, currentManifest); err != nil {
+			fmt.Println("error writing manifest:", err)
+			os.Exit(1)
+		}
 
-	        if i + 1 == block {
-	            taskFiles[i] = allFilePaths[start:]
-	            break
-	        }
+		checkpoint, checkpointErr := newCheckpointLog(userArgs.tempFolder, startChunk)
+		if checkpointErr != nil {
+			fmt.Println("error opening checkpoint log:", checkpointErr)
+			os.Exit(1)
+		}
+		defer checkpoint.Close()
 
-	        end := start + split
-	        taskFiles[i] = allFilePaths[start:end]
+		err = runPipeline(ctx, allFilePaths, userArgs, collector, checkpoint, skip)
+	}
 
-	        go process(taskFiles[i], userArgs, numberOfFiles, ch)
-	    }
-	**/
+	if err != nil && err != context.Canceled {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
 
+	if err := emitResults(collector, userArgs); err != nil {
+		fmt.Println("error writing results:", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+
+	mapset "github.com/deckarep/golang-set"
+)
+
+// minhashPrime is the first prime above 2^32, used as the modulus for the
+// h(x) = a*x + b mod p hash family. Keeping a, b, x within uint32 means
+// a*x always fits in a uint64 without overflow.
+const minhashPrime = 4294967311
+
+// hashFunc is one member of the h_i(x) = a*x + b mod p family used to build
+// MinHash signatures.
+type hashFunc struct {
+	a, b uint32
+}
+
+// newMinHashFuncs builds the hash family from seed, not wall-clock time, so
+// the same --seed against the same files always yields the same candidate
+// pairs (see --seed in input()).
+func newMinHashFuncs(k int, seed int64) []hashFunc {
+	rnd := rand.New(rand.NewSource(seed))
+	funcs := make([]hashFunc, k)
+	for i := range funcs {
+		funcs[i] = hashFunc{a: rnd.Uint32() | 1, b: rnd.Uint32()}
+	}
+	return funcs
+}
+
+func (h hashFunc) apply(x uint32) uint64 {
+	return (uint64(h.a)*uint64(x) + uint64(h.b)) % minhashPrime
+}
+
+func hash32(member interface{}) uint32 {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%v", member)
+	return h.Sum32()
+}
+
+// minhashSignature computes the MinHash signature of set under funcs: for
+// each hash function, the minimum hash value seen over every member of set.
+func minhashSignature(set mapset.Set, funcs []hashFunc) []uint64 {
+	sig := make([]uint64, len(funcs))
+	for i := range sig {
+		sig[i] = ^uint64(0)
+	}
+
+	for member := range set.Iter() {
+		x := hash32(member)
+		for i, hf := range funcs {
+			if v := hf.apply(x); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+
+	return sig
+}
+
+// bandKey identifies one LSH bucket: a band index plus the hash of that
+// band's row-tuple. Two files only become candidates if they collide here.
+type bandKey struct {
+	band int
+	hash uint64
+}
+
+// lshCandidates buckets every file's signature by band and returns the
+// deduplicated pairs that collided in at least one band.
+func lshCandidates(filePaths []string, signatures map[string][]uint64, bands, rows int) [][2]string {
+	buckets := make(map[bandKey][]string)
+
+	for _, path := range filePaths {
+		sig := signatures[path]
+		for b := 0; b < bands; b++ {
+			h := fnv.New64a()
+			for r := 0; r < rows; r++ {
+				binary.Write(h, binary.LittleEndian, sig[b*rows+r])
+			}
+			key := bandKey{band: b, hash: h.Sum64()}
+			buckets[key] = append(buckets[key], path)
+		}
+	}
+
+	seen := make(map[[2]string]bool)
+	var candidates [][2]string
+	for _, group := range buckets {
+		for i := 0; i < len(group); i++ {
+			for j := i + 1; j < len(group); j++ {
+				pair := orderedPair(group[i], group[j])
+				if !seen[pair] {
+					seen[pair] = true
+					candidates = append(candidates, pair)
+				}
+			}
+		}
+	}
+
+	return candidates
+}
+
+func orderedPair(a, b string) [2]string {
+	if a < b {
+		return [2]string{a, b}
+	}
+	return [2]string{b, a}
+}
+
+// runApprox builds a MinHash signature per file, narrows the N^2 comparison
+// space down to LSH candidate pairs, and only runs the exact jaccard() on
+// those. b and r should be chosen so the similarity S-curve
+// 1-(1-s^r)^b inflects near userArgs.jaccardThreshold. Cancelling ctx (e.g.
+// on os.Interrupt) stops the extraction loop before it runs to completion,
+// the same way runPipeline responds to cancellation.
+func runApprox(ctx context.Context, filePaths []string, userArgs *args, collector *ResultCollector) error {
+	extractor := featureExtractors[userArgs.features](userArgs)
+	funcs := newMinHashFuncs(userArgs.numHashes, userArgs.seed)
+
+	features := make(map[string]mapset.Set, len(filePaths))
+	signatures := make(map[string][]uint64, len(filePaths))
+	// validPaths excludes any file whose extraction failed, so lshCandidates
+	// never has to look up a signature that was never computed.
+	validPaths := make([]string, 0, len(filePaths))
+
+	for _, path := range filePaths {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		set, err := extractor.Extract(path)
+		if err != nil {
+			fmt.Printf("skipping %s: %v\n", path, err)
+			continue
+		}
+		features[path] = set
+		signatures[path] = minhashSignature(set, funcs)
+		validPaths = append(validPaths, path)
+	}
+
+	candidates := lshCandidates(validPaths, signatures, userArgs.bands, userArgs.rows)
+	fmt.Printf("Candidates: %d (of %d possible pairs)\n", len(candidates), len(validPaths)*(len(validPaths)-1)/2)
+
+	for _, pair := range candidates {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		jaccardIndex := jaccard(features[pair[0]], features[pair[1]])
+		if jaccardIndex > userArgs.jaccardThreshold {
+			collector.Add(pair[0], pair[1], jaccardIndex)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	mapset "github.com/deckarep/golang-set"
+)
+
+func TestNewMinHashFuncsDeterministic(t *testing.T) {
+	a := newMinHashFuncs(16, 42)
+	b := newMinHashFuncs(16, 42)
+
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("hash func %d differs between runs with the same seed", i)
+		}
+	}
+
+	c := newMinHashFuncs(16, 43)
+	same := true
+	for i := range a {
+		if a[i] != c[i] {
+			same = false
+		}
+	}
+	if same {
+		t.Fatal("expected a different seed to produce a different hash family")
+	}
+}
+
+func TestLSHCandidatesFindsMatchingBucket(t *testing.T) {
+	signatures := map[string][]uint64{
+		"a": {1, 2, 3, 4},
+		"b": {1, 2, 3, 4},
+		"c": {9, 9, 9, 9},
+	}
+
+	candidates := lshCandidates([]string{"a", "b", "c"}, signatures, 2, 2)
+
+	want := orderedPair("a", "b")
+	found := false
+	for _, pair := range candidates {
+		if pair == want {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected identical signatures to land in the same LSH bucket, got %v", candidates)
+	}
+}
+
+func TestMinhashSignatureDeterministic(t *testing.T) {
+	set := mapset.NewSet("foo", "bar", "baz")
+	funcs := newMinHashFuncs(32, 7)
+
+	sig1 := minhashSignature(set, funcs)
+	sig2 := minhashSignature(set, funcs)
+
+	for i := range sig1 {
+		if sig1[i] != sig2[i] {
+			t.Fatalf("signature entry %d differs across identical runs", i)
+		}
+	}
+}
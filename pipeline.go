@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	mapset "github.com/deckarep/golang-set"
+	"github.com/ernestosuarez/itertools"
+)
+
+// filePair is one candidate comparison fed to the worker pool.
+type filePair struct {
+	index int
+	a, b  string
+}
+
+// runPipeline extracts features for every file, then scores every pair
+// through a bounded worker pool: a bounded work channel feeds userArgs.workers
+// goroutines, which push results onto a bounded output channel drained by a
+// single collector goroutine. Cancelling ctx (e.g. on os.Interrupt) stops
+// feeding new pairs and lets in-flight workers return promptly.
+//
+// checkpoint, if non-nil, gets every scored pair appended to it regardless
+// of threshold, and skip marks pair indices already present in a resumed
+// checkpoint so they aren't rescored.
+func runPipeline(ctx context.Context, filePaths []string, userArgs *args, collector *ResultCollector, checkpoint *checkpointLog, skip map[int]bool) error {
+	extractor := featureExtractors[userArgs.features](userArgs)
+	fileFeatures := make(map[string]mapset.Set, len(filePaths))
+	// validPaths excludes any file whose extraction failed, so a single
+	// unreadable file doesn't produce pairs with no entry in fileFeatures.
+	validPaths := make([]string, 0, len(filePaths))
+
+	for _, path := range filePaths {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		features, err := extractor.Extract(path)
+		if err != nil {
+			fmt.Printf("skipping %s: %v\n", path, err)
+			continue
+		}
+		fileFeatures[path] = features
+		validPaths = append(validPaths, path)
+	}
+
+	work := make(chan filePair, userArgs.workers*2)
+	results := make(chan Result, userArgs.workers*2)
+
+	var workers sync.WaitGroup
+	for i := 0; i < userArgs.workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for pair := range work {
+				jaccardIndex := jaccard(fileFeatures[pair.a], fileFeatures[pair.b])
+
+				if checkpoint != nil {
+					entry := logEntry{Index: pair.index, PathA: pair.a, PathB: pair.b, Score: jaccardIndex}
+					if err := checkpoint.Append(entry); err != nil {
+						fmt.Printf("checkpoint: failed to log pair %d: %v\n", pair.index, err)
+					}
+				}
+
+				if jaccardIndex <= userArgs.jaccardThreshold {
+					continue
+				}
+
+				select {
+				case results <- Result{PathA: pair.a, PathB: pair.b, Score: jaccardIndex}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for r := range results {
+			collector.Add(r.PathA, r.PathB, r.Score)
+		}
+	}()
+
+	idx := 0
+	// itertools.GenCombinations panics if asked for pairs from fewer than 2
+	// items, which a target with 0 or 1 readable files would otherwise hit.
+	if len(validPaths) >= 2 {
+	feed:
+		for fArray := range itertools.CombinationsStr(validPaths, 2) {
+			if !skip[idx] {
+				select {
+				case work <- filePair{index: idx, a: fArray[0], b: fArray[1]}:
+				case <-ctx.Done():
+					break feed
+				}
+			}
+			idx++
+		}
+	}
+	close(work)
+
+	workers.Wait()
+	close(results)
+	<-collected
+
+	return ctx.Err()
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunPipelineFindsSimilarFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	a := write("a.txt", "hello world foo bar\n")
+	b := write("b.txt", "hello world foo bar\n")
+	c := write("c.txt", "completely different content here\n")
+
+	userArgs := &args{
+		features:         "strings",
+		jaccardThreshold: 0.5,
+		workers:          2,
+		minLen:           4,
+		encoding:         "ascii",
+	}
+
+	collector := &ResultCollector{}
+	if err := runPipeline(context.Background(), []string{a, b, c}, userArgs, collector, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	sorted := collector.Sorted()
+	if len(sorted) != 1 {
+		t.Fatalf("expected exactly one pair above threshold, got %d: %+v", len(sorted), sorted)
+	}
+	if sorted[0].PathA != a || sorted[0].PathB != b {
+		t.Fatalf("expected %s/%s to match, got %+v", a, b, sorted[0])
+	}
+}
+
+func TestRunPipelineSkipsUnreadableFiles(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(a, []byte("hello world\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "does-not-exist.txt")
+
+	userArgs := &args{
+		features:         "strings",
+		jaccardThreshold: 0.1,
+		workers:          1,
+		minLen:           4,
+		encoding:         "ascii",
+	}
+
+	collector := &ResultCollector{}
+	if err := runPipeline(context.Background(), []string{a, missing}, userArgs, collector, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(collector.Sorted()) != 0 {
+		t.Fatalf("expected no pairs with only one readable file, got %+v", collector.Sorted())
+	}
+}
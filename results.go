@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Result is one (pathA, pathB, score) triple that scored above the
+// jaccard threshold.
+type Result struct {
+	PathA string  `json:"pathA"`
+	PathB string  `json:"pathB"`
+	Score float32 `json:"score"`
+}
+
+// ResultCollector gathers results from every worker so they can be sorted
+// and emitted deterministically, instead of printed in whatever order the
+// goroutines happen to finish.
+type ResultCollector struct {
+	results []Result
+}
+
+// Add records a result above threshold.
+func (c *ResultCollector) Add(pathA, pathB string, score float32) {
+	c.results = append(c.results, Result{PathA: pathA, PathB: pathB, Score: score})
+}
+
+// Sorted returns the collected results ordered by score descending, then
+// lexicographically by (pathA, pathB), so output is reproducible across runs.
+func (c *ResultCollector) Sorted() []Result {
+	sorted := make([]Result, len(c.results))
+	copy(sorted, c.results)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Score != sorted[j].Score {
+			return sorted[i].Score > sorted[j].Score
+		}
+		if sorted[i].PathA != sorted[j].PathA {
+			return sorted[i].PathA < sorted[j].PathA
+		}
+		return sorted[i].PathB < sorted[j].PathB
+	})
+
+	return sorted
+}
+
+// Formatter renders a sorted result set.
+type Formatter interface {
+	Format(w io.Writer, results []Result) error
+}
+
+// formatters is the --format registry.
+var formatters = map[string]Formatter{
+	"text":   TextFormatter{},
+	"csv":    CSVFormatter{},
+	"ndjson": NDJSONFormatter{},
+	"json":   JSONFormatter{},
+}
+
+// TextFormatter reproduces the original human-readable line format.
+type TextFormatter struct{}
+
+func (TextFormatter) Format(w io.Writer, results []Result) error {
+	for _, r := range results {
+		if _, err := fmt.Fprintf(w, "%s -- %s :: %f\n", r.PathA, r.PathB, r.Score); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CSVFormatter writes a header row followed by one row per result.
+type CSVFormatter struct{}
+
+func (CSVFormatter) Format(w io.Writer, results []Result) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write([]string{"pathA", "pathB", "score"}); err != nil {
+		return err
+	}
+	for _, r := range results {
+		row := []string{r.PathA, r.PathB, fmt.Sprintf("%f", r.Score)}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// NDJSONFormatter writes one JSON object per line.
+type NDJSONFormatter struct{}
+
+func (NDJSONFormatter) Format(w io.Writer, results []Result) error {
+	enc := json.NewEncoder(w)
+	for _, r := range results {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// JSONFormatter writes the whole result set as a single JSON array.
+type JSONFormatter struct{}
+
+func (JSONFormatter) Format(w io.Writer, results []Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
+
+// emitResults sorts and writes the collected results using the format and
+// destination the user asked for.
+func emitResults(collector *ResultCollector, userArgs *args) error {
+	formatter, ok := formatters[userArgs.format]
+	if !ok {
+		return fmt.Errorf("unknown output format %q", userArgs.format)
+	}
+
+	var out io.Writer = os.Stdout
+	if userArgs.output != "" {
+		f, err := os.Create(userArgs.output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	return formatter.Format(out, collector.Sorted())
+}
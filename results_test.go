@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestResultCollectorSorted(t *testing.T) {
+	c := &ResultCollector{}
+	c.Add("b", "c", 0.5)
+	c.Add("a", "b", 0.9)
+	c.Add("a", "c", 0.9)
+
+	sorted := c.Sorted()
+	if len(sorted) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(sorted))
+	}
+	if sorted[0].Score != 0.9 || sorted[0].PathA != "a" || sorted[0].PathB != "b" {
+		t.Fatalf("expected highest score with lexicographic tie-break first, got %+v", sorted[0])
+	}
+	if sorted[2].Score != 0.5 {
+		t.Fatalf("expected lowest score last, got %+v", sorted[2])
+	}
+}
+
+func TestFormatters(t *testing.T) {
+	results := []Result{{PathA: "a", PathB: "b", Score: 0.75}}
+
+	var textBuf bytes.Buffer
+	if err := (TextFormatter{}).Format(&textBuf, results); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(textBuf.String(), "a -- b") {
+		t.Fatalf("unexpected text output: %q", textBuf.String())
+	}
+
+	var csvBuf bytes.Buffer
+	if err := (CSVFormatter{}).Format(&csvBuf, results); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(csvBuf.String(), "pathA,pathB,score") {
+		t.Fatalf("expected CSV header, got %q", csvBuf.String())
+	}
+
+	var jsonBuf bytes.Buffer
+	if err := (JSONFormatter{}).Format(&jsonBuf, results); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(jsonBuf.String(), `"pathA": "a"`) {
+		t.Fatalf("unexpected JSON output: %q", jsonBuf.String())
+	}
+}
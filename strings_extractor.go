@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"unicode"
+	"unicode/utf8"
+
+	mapset "github.com/deckarep/golang-set"
+)
+
+// stringsBufferSize is the read buffer size used to stream a file instead
+// of loading it whole, the way the old strings(1) subprocess did via
+// CombinedOutput.
+const stringsBufferSize = 64 * 1024
+
+// defaultMinLen matches GNU strings' default minimum run length.
+const defaultMinLen = 4
+
+// StringsExtractor streams a file and emits each maximal run of printable
+// characters of at least MinLen as a set member - the same "printable-run"
+// algorithm strings(1) uses, just in-process. It replaces the old
+// exec.Command("strings", ...) call, which forked a process per file,
+// didn't work on Windows, and buffered the whole subprocess output in
+// memory.
+type StringsExtractor struct {
+	MinLen   int
+	Encoding string
+}
+
+// Extract returns the printable-run string set for fullpath.
+func (s StringsExtractor) Extract(fullpath string) (mapset.Set, error) {
+	minLen := s.MinLen
+	if minLen <= 0 {
+		minLen = defaultMinLen
+	}
+
+	encodings, err := encodingsFor(s.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	result := mapset.NewSet()
+	for _, enc := range encodings {
+		f, err := os.Open(fullpath)
+		if err != nil {
+			return nil, err
+		}
+
+		set, err := extractRuns(f, minLen, enc)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for member := range set.Iter() {
+			result.Add(member)
+		}
+	}
+
+	return result, nil
+}
+
+// encodingsFor expands "all" into every concrete encoding and validates
+// everything else.
+func encodingsFor(encoding string) ([]string, error) {
+	switch encoding {
+	case "":
+		return []string{"ascii"}, nil
+	case "ascii", "utf8", "utf16le", "utf16be":
+		return []string{encoding}, nil
+	case "all":
+		return []string{"ascii", "utf8", "utf16le", "utf16be"}, nil
+	default:
+		return nil, fmt.Errorf("unknown string encoding %q", encoding)
+	}
+}
+
+func extractRuns(r io.Reader, minLen int, encoding string) (mapset.Set, error) {
+	switch encoding {
+	case "ascii":
+		return extractASCIIRuns(r, minLen)
+	case "utf8":
+		return extractUTF8Runs(r, minLen)
+	case "utf16le":
+		return extractUTF16Runs(r, minLen, binary.LittleEndian)
+	case "utf16be":
+		return extractUTF16Runs(r, minLen, binary.BigEndian)
+	default:
+		return nil, fmt.Errorf("unknown string encoding %q", encoding)
+	}
+}
+
+// isPrintableASCII matches GNU strings' default character set: tab plus the
+// printable range 0x20-0x7e.
+func isPrintableASCII(b byte) bool {
+	return b == '\t' || (b >= 0x20 && b <= 0x7e)
+}
+
+func extractASCIIRuns(r io.Reader, minLen int) (mapset.Set, error) {
+	set := mapset.NewSet()
+	br := bufio.NewReaderSize(r, stringsBufferSize)
+	var run []byte
+
+	flush := func() {
+		if len(run) >= minLen {
+			set.Add(string(run))
+		}
+		run = run[:0]
+	}
+
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			flush()
+			return set, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if isPrintableASCII(b) {
+			run = append(run, b)
+		} else {
+			flush()
+		}
+	}
+}
+
+func extractUTF8Runs(r io.Reader, minLen int) (mapset.Set, error) {
+	set := mapset.NewSet()
+	br := bufio.NewReaderSize(r, stringsBufferSize)
+	var run []rune
+
+	flush := func() {
+		if len(run) >= minLen {
+			set.Add(string(run))
+		}
+		run = run[:0]
+	}
+
+	for {
+		rn, _, err := br.ReadRune()
+		if err == io.EOF {
+			flush()
+			return set, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if rn != utf8.RuneError && unicode.IsPrint(rn) {
+			run = append(run, rn)
+		} else {
+			flush()
+		}
+	}
+}
+
+// extractUTF16Runs handles wide strings (common in PE binaries): each code
+// unit is read as a 2-byte value in the given byte order and treated as
+// printable if it falls in the same ASCII-printable range that
+// isPrintableASCII accepts, which covers the overwhelming majority of embedded wide
+// strings without the complexity of full surrogate-pair decoding.
+func extractUTF16Runs(r io.Reader, minLen int, order binary.ByteOrder) (mapset.Set, error) {
+	set := mapset.NewSet()
+	br := bufio.NewReaderSize(r, stringsBufferSize)
+	var run []byte
+	unit := make([]byte, 2)
+
+	flush := func() {
+		if len(run) >= minLen {
+			set.Add(string(run))
+		}
+		run = run[:0]
+	}
+
+	for {
+		_, err := io.ReadFull(br, unit)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			flush()
+			return set, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		code := order.Uint16(unit)
+		if code == '\t' || (code >= 0x20 && code <= 0x7e) {
+			run = append(run, byte(code))
+		} else {
+			flush()
+		}
+	}
+}
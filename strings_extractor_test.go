@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestExtractASCIIRunsRespectsMinLen(t *testing.T) {
+	data := []byte("ab\x00hello\x01world!\x02ok")
+	set, err := extractASCIIRuns(bytes.NewReader(data), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !set.Contains("hello") {
+		t.Fatalf("expected 'hello' to be extracted, got %v", set)
+	}
+	if !set.Contains("world!") {
+		t.Fatalf("expected 'world!' to be extracted, got %v", set)
+	}
+	if set.Contains("ab") {
+		t.Fatalf("expected short run 'ab' to be dropped by min-len, got %v", set)
+	}
+	if set.Contains("ok") {
+		t.Fatalf("expected short run 'ok' to be dropped by min-len, got %v", set)
+	}
+}
+
+func TestExtractUTF16RunsRespectsMinLen(t *testing.T) {
+	var buf bytes.Buffer
+	for _, r := range "hello" {
+		binary.Write(&buf, binary.LittleEndian, uint16(r))
+	}
+	binary.Write(&buf, binary.LittleEndian, uint16(0))
+	for _, r := range "hi" {
+		binary.Write(&buf, binary.LittleEndian, uint16(r))
+	}
+
+	set, err := extractUTF16Runs(bytes.NewReader(buf.Bytes()), 4, binary.LittleEndian)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !set.Contains("hello") {
+		t.Fatalf("expected 'hello' to be extracted from UTF-16LE, got %v", set)
+	}
+	if set.Contains("hi") {
+		t.Fatalf("expected short run 'hi' to be dropped by min-len, got %v", set)
+	}
+}
+
+func TestEncodingsForAllExpandsToEveryEncoding(t *testing.T) {
+	encs, err := encodingsFor("all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(encs) != 4 {
+		t.Fatalf("expected 4 encodings for \"all\", got %d", len(encs))
+	}
+}
+
+func TestEncodingsForUnknownEncodingErrors(t *testing.T) {
+	if _, err := encodingsFor("bogus"); err == nil {
+		t.Fatal("expected an error for an unknown encoding")
+	}
+}